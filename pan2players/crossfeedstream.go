@@ -0,0 +1,148 @@
+// Copyright 2020 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"math"
+	"sync/atomic"
+)
+
+// maxCrossfeedDelaySamples bounds the crossfeed delay line. It comfortably
+// covers a few milliseconds of delay at the demo's sample rate, far more
+// than the ~300us Bauer-style crossfeed calls for.
+const maxCrossfeedDelaySamples = 256
+
+// CrossfeedParams configures a Bauer-style headphone crossfeed: a low-passed,
+// delayed copy of each channel mixed into the opposite channel, which
+// narrows an unnaturally wide (e.g. fully hard-panned) stereo image for
+// headphone listening.
+type CrossfeedParams struct {
+	SampleRate int
+
+	// CutoffHz is the first-order low-pass cutoff applied to the crossfeed
+	// path, typically around 700 Hz.
+	CutoffHz float64
+
+	// DelayMicros is the delay applied to the crossfeed path, typically
+	// around 300us.
+	DelayMicros float64
+
+	// LevelDB is the crossfeed mix level, typically around -3dB.
+	LevelDB float64
+}
+
+// crossfeedCoeffs are CrossfeedParams translated into per-sample processing
+// constants, recomputed whenever SetParams is called.
+type crossfeedCoeffs struct {
+	lowPassAlpha float64
+	delaySamples int
+	level        float64
+}
+
+func computeCrossfeedCoeffs(p CrossfeedParams) crossfeedCoeffs {
+	dt := 1 / float64(p.SampleRate)
+	rc := 1 / (2 * math.Pi * p.CutoffHz)
+	return crossfeedCoeffs{
+		lowPassAlpha: dt / (rc + dt),
+		delaySamples: clampCrossfeedDelay(int(p.DelayMicros/1e6*float64(p.SampleRate) + 0.5)),
+		level:        math.Pow(10, p.LevelDB/20),
+	}
+}
+
+func clampCrossfeedDelay(samples int) int {
+	if samples < 0 {
+		return 0
+	}
+	if samples > maxCrossfeedDelaySamples-1 {
+		return maxCrossfeedDelaySamples - 1
+	}
+	return samples
+}
+
+// CrossfeedStream decorates any io.ReadSeeker of interleaved stereo F32
+// frames with an optional crossfeed filter, meant to run after panning in
+// the stream pipeline (e.g. wrapping a StereoPanStream).
+//
+// SetParams and SetEnabled may be called concurrently with Read from another
+// goroutine, so the coefficients and enabled flag are stored atomically.
+type CrossfeedStream struct {
+	src io.ReadSeeker
+
+	enabled atomic.Bool
+	coeffs  atomic.Pointer[crossfeedCoeffs]
+
+	// The remaining fields are only touched from Read, which Ebitengine
+	// guarantees is never called concurrently with itself.
+	lowPassLeft, lowPassRight float64
+	ringLeft, ringRight       [maxCrossfeedDelaySamples]float32
+	ringPos                   int
+}
+
+// NewCrossfeedStream creates a CrossfeedStream reading frames from src,
+// enabled by default with the given parameters.
+func NewCrossfeedStream(src io.ReadSeeker, params CrossfeedParams) *CrossfeedStream {
+	s := &CrossfeedStream{src: src}
+	s.enabled.Store(true)
+	s.SetParams(params)
+	return s
+}
+
+// SetParams recomputes the filter coefficients for new parameters, e.g.
+// after a sample rate change. It is safe to call from any goroutine.
+func (s *CrossfeedStream) SetParams(params CrossfeedParams) {
+	c := computeCrossfeedCoeffs(params)
+	s.coeffs.Store(&c)
+}
+
+// SetEnabled turns the crossfeed effect on or off; Read passes samples
+// through unmodified while disabled. It is safe to call from any goroutine.
+func (s *CrossfeedStream) SetEnabled(enabled bool) {
+	s.enabled.Store(enabled)
+}
+
+func (s *CrossfeedStream) Read(p []byte) (int, error) {
+	n, err := s.src.Read(p)
+	if !s.enabled.Load() {
+		return n, err
+	}
+
+	c := *s.coeffs.Load()
+
+	// Each stereo float32 frame is 8 bytes: 4 bytes left + 4 bytes right.
+	for i := 0; i+8 <= n; i += 8 {
+		left := readFloat32(p[i : i+4])
+		right := readFloat32(p[i+4 : i+8])
+
+		s.ringLeft[s.ringPos%maxCrossfeedDelaySamples] = left
+		s.ringRight[s.ringPos%maxCrossfeedDelaySamples] = right
+		s.ringPos++
+
+		delayedLeft := s.ringLeft[ringIndex(s.ringPos, c.delaySamples, maxCrossfeedDelaySamples)]
+		delayedRight := s.ringRight[ringIndex(s.ringPos, c.delaySamples, maxCrossfeedDelaySamples)]
+
+		s.lowPassLeft += c.lowPassAlpha * (float64(delayedLeft) - s.lowPassLeft)
+		s.lowPassRight += c.lowPassAlpha * (float64(delayedRight) - s.lowPassRight)
+
+		writeFloat32(p[i:i+4], left+float32(s.lowPassRight*c.level))
+		writeFloat32(p[i+4:i+8], right+float32(s.lowPassLeft*c.level))
+	}
+
+	return n, err
+}
+
+func (s *CrossfeedStream) Seek(offset int64, whence int) (int64, error) {
+	return s.src.Seek(offset, whence)
+}
@@ -19,7 +19,6 @@ import (
 	"fmt"
 	"image"
 	_ "image/png"
-	"io"
 	"log"
 	"math"
 	"time"
@@ -30,34 +29,74 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	raudio "github.com/hajimehoshi/ebiten/v2/examples/resources/audio"
 	"github.com/hajimehoshi/ebiten/v2/examples/resources/images"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/31/ebitengine-panning-compare/spatial"
 )
 
 const (
 	screenWidth  = 640
 	screenHeight = 480
 	sampleRate   = 48000
+
+	// The source orbits the listener at a radius that breathes between
+	// orbitMinRadius and orbitMaxRadius, so distance attenuation is audible
+	// as well as panning.
+	orbitMinRadius = 80
+	orbitMaxRadius = 260
 )
 
+// defaultCrossfeedParams are typical Bauer-style crossfeed settings: a
+// 700 Hz low-pass on the crossfeed path, a 300us delay, and a -3dB mix
+// level.
+var defaultCrossfeedParams = CrossfeedParams{
+	SampleRate:  sampleRate,
+	CutoffHz:    700,
+	DelayMicros: 300,
+	LevelDB:     -3,
+}
+
+// listener sits at the world origin, facing screen "up" (-Y).
+var listener = spatial.Listener{Position: spatial.Vec2{}}
+
+// atten configures distance falloff for the orbiting source.
+var atten = spatial.AttenuationParams{
+	Model:         spatial.InverseClamped,
+	RefDistance:   orbitMinRadius,
+	MaxDistance:   orbitMaxRadius,
+	RolloffFactor: 1,
+}
+
 var ebitenImage *ebiten.Image
 
 type Game struct {
-	playerLeft  *audio.Player
-	playerRight *audio.Player
+	player    *audio.Player
+	stream    *StereoPanStream
+	crossfeed *CrossfeedStream
 
-	// panning goes from -1 to 1
-	// -1: 100% left channel, 0% right channel
-	// 0: 100% both channels
-	// 1: 0% left channel, 100% right channel
-	panning float64
+	crossfeedOn bool
 
 	count int
-	xpos  float64
+
+	// sourcePos is the orbiting source's position in world space, relative
+	// to the listener at the origin.
+	sourcePos spatial.Vec2
+	placement spatial.Placement
 
 	audioContext *audio.Context
+
+	panLaws   []PanLaw
+	panLawIdx int
+	leftGain  float64
+	rightGain float64
+}
+
+func (g *Game) panLaw() PanLaw {
+	return g.panLaws[g.panLawIdx]
 }
 
 func (g *Game) initAudioIfNeeded() {
-	if g.playerLeft != nil {
+	if g.player != nil {
 		return
 	}
 
@@ -65,28 +104,19 @@ func (g *Game) initAudioIfNeeded() {
 		g.audioContext = audio.NewContext(sampleRate)
 	}
 
-	oggSL, err := vorbis.DecodeF32(bytes.NewReader(raudio.Ragtime_ogg))
-	if err != nil {
-		log.Fatal(err)
-	}
-	leftStream := NewSingleChannelStream(audio.NewInfiniteLoop(oggSL, oggSL.Length()), true)
-	g.playerLeft, err = g.audioContext.NewPlayerF32(leftStream)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	oggSR, err := vorbis.DecodeF32(bytes.NewReader(raudio.Ragtime_ogg))
+	oggS, err := vorbis.DecodeF32(bytes.NewReader(raudio.Ragtime_ogg))
 	if err != nil {
 		log.Fatal(err)
 	}
-	rightStream := NewSingleChannelStream(audio.NewInfiniteLoop(oggSR, oggSR.Length()), false)
-	g.playerRight, err = g.audioContext.NewPlayerF32(rightStream)
+	g.stream = NewStereoPanStream(audio.NewInfiniteLoop(oggS, oggS.Length()), g.panLaw())
+	g.crossfeed = NewCrossfeedStream(g.stream, defaultCrossfeedParams)
+	g.crossfeed.SetEnabled(g.crossfeedOn)
+	g.player, err = g.audioContext.NewPlayerF32(g.crossfeed)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	g.playerLeft.Play()
-	g.playerRight.Play()
+	g.player.Play()
 }
 
 // time is within the 0 ... 1 range
@@ -94,37 +124,75 @@ func lerp(a, b, t float64) float64 {
 	return a*(1-t) + b*t
 }
 
+func onOff(v bool) string {
+	if v {
+		return "on"
+	}
+	return "off"
+}
+
 func (g *Game) Update() error {
 	g.count++
-	r := float64(g.count) * ((1.0 / 60.0) * 2 * math.Pi) * 0.1 // full cycle every 10 seconds
-	g.xpos = (float64(screenWidth) / 2) + math.Cos(r)*(float64(screenWidth)/2)
-	g.panning = lerp(-1, 1, g.xpos/float64(screenWidth))
+	orbitAngle := float64(g.count) * ((1.0 / 60.0) * 2 * math.Pi) * 0.1   // full orbit every 10 seconds
+	radiusAngle := float64(g.count) * ((1.0 / 60.0) * 2 * math.Pi) * 0.04 // breathe slower than the orbit
+	radius := lerp(orbitMinRadius, orbitMaxRadius, (math.Sin(radiusAngle)+1)/2)
+	g.sourcePos = spatial.Vec2{X: math.Cos(orbitAngle) * radius, Y: math.Sin(orbitAngle) * radius}
 
-	// Initialize the audio after the panning is determined.
+	// Initialize the audio after the source position is determined.
 	g.initAudioIfNeeded()
 
-	// Adjust each player's volume to achieve panning.
-	// This uses the same linear scale as the original StereoPanStream.
-	leftVolume := math.Min(g.panning*-1+1, 1)
-	rightVolume := math.Min(g.panning+1, 1)
-	g.playerLeft.SetVolume(leftVolume)
-	g.playerRight.SetVolume(rightVolume)
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		g.panLawIdx = (g.panLawIdx + 1) % len(g.panLaws)
+		g.stream.SetPanLaw(g.panLaw())
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		g.crossfeedOn = !g.crossfeedOn
+		g.crossfeed.SetEnabled(g.crossfeedOn)
+	}
+
+	g.placement = spatial.Compute(listener, g.sourcePos, atten, sampleRate)
+	g.stream.SetPan(g.placement.Azimuth)
+	g.stream.SetGain(g.placement.Gain)
+	g.stream.SetITD(g.placement.ITDLeftSamples, g.placement.ITDRightSamples)
+
+	// These are the target gains for the overlay; the stream ramps toward
+	// them sample-by-sample to avoid zipper noise.
+	g.leftGain, g.rightGain = g.panLaw().Gains(g.placement.Azimuth)
+	g.leftGain *= g.placement.Gain
+	g.rightGain *= g.placement.Gain
 
 	return nil
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	pos := g.playerLeft.Position()
+	pos := g.player.Position()
 	msg := fmt.Sprintf(`TPS: %0.2f
-This is an example using
-stereo audio panning (2 players).
+This is an example using 3D positional audio
+(StereoPanStream + spatial package).
 Current: %0.2f[s]
-Panning: %.2f`, ebiten.ActualTPS(), float64(pos)/float64(time.Second), g.panning)
+Pan law: %s (space to cycle)
+Crossfeed: %s (C to toggle)
+Distance:   %.1f
+Azimuth:    %.2f
+Atten gain: %.3f (%.1f dB)
+ITD:        L %d / R %d samples
+Left:  %.3f (%.1f dB)
+Right: %.3f (%.1f dB)`,
+		ebiten.ActualTPS(), float64(pos)/float64(time.Second),
+		g.panLaw().Name(),
+		onOff(g.crossfeedOn),
+		g.placement.Distance, g.placement.Azimuth,
+		g.placement.Gain, gainToDB(g.placement.Gain),
+		g.placement.ITDLeftSamples, g.placement.ITDRightSamples,
+		g.leftGain, gainToDB(g.leftGain), g.rightGain, gainToDB(g.rightGain))
 	ebitenutil.DebugPrint(screen, msg)
 
-	// draw image to show where the sound is at related to the screen
+	// Draw the source sprite at its world position, relative to the
+	// listener at screen center.
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(g.xpos-float64(ebitenImage.Bounds().Dx()/2), screenHeight/2)
+	screenX := float64(screenWidth)/2 + g.sourcePos.X - float64(ebitenImage.Bounds().Dx())/2
+	screenY := float64(screenHeight)/2 + g.sourcePos.Y - float64(ebitenImage.Bounds().Dy())/2
+	op.GeoM.Translate(screenX, screenY)
 	screen.DrawImage(ebitenImage, op)
 }
 
@@ -142,44 +210,11 @@ func main() {
 
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	ebiten.SetWindowTitle("Audio Panning Loop (Ebitengine Demo)")
-	g := &Game{}
+	g := &Game{
+		panLaws:     []PanLaw{LinearPan{}, ConstantPowerPan{}, CompromisePan{}},
+		crossfeedOn: true,
+	}
 	if err := ebiten.RunGame(g); err != nil {
 		log.Fatal(err)
 	}
 }
-
-// SingleChannelStream passes audio for only one stereo channel, silencing the other.
-// This lets each player be volume-controlled independently for panning.
-type SingleChannelStream struct {
-	io.ReadSeeker
-	isLeft bool
-}
-
-func (s *SingleChannelStream) Read(p []byte) (int, error) {
-	n, err := s.ReadSeeker.Read(p)
-	// Each stereo float32 frame is 8 bytes: 4 bytes left + 4 bytes right.
-	// Zero out the unwanted channel for every complete frame.
-	for i := 0; i+8 <= n; i += 8 {
-		if s.isLeft {
-			// Silence the right channel.
-			p[i+4] = 0
-			p[i+5] = 0
-			p[i+6] = 0
-			p[i+7] = 0
-		} else {
-			// Silence the left channel.
-			p[i] = 0
-			p[i+1] = 0
-			p[i+2] = 0
-			p[i+3] = 0
-		}
-	}
-	return n, err
-}
-
-func NewSingleChannelStream(src io.ReadSeeker, isLeft bool) *SingleChannelStream {
-	return &SingleChannelStream{
-		ReadSeeker: src,
-		isLeft:     isLeft,
-	}
-}
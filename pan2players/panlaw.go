@@ -0,0 +1,74 @@
+// Copyright 2020 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "math"
+
+// PanLaw maps a panning value in the range [-1, 1] to linear left/right
+// channel gains. -1 is fully left, 0 is center, and 1 is fully right.
+type PanLaw interface {
+	// Name returns a short human-readable name for on-screen display.
+	Name() string
+
+	// Gains returns the linear left and right gain for the given panning value.
+	Gains(panning float64) (left, right float64)
+}
+
+// LinearPan is a simple crossfade between channels. At center, both channels
+// are at full volume (0 dB), which sums to roughly +6 dB in the middle
+// compared to the edges.
+type LinearPan struct{}
+
+func (LinearPan) Name() string { return "Linear" }
+
+func (LinearPan) Gains(panning float64) (left, right float64) {
+	left = math.Min(panning*-1+1, 1)
+	right = math.Min(panning+1, 1)
+	return left, right
+}
+
+// ConstantPowerPan keeps perceived loudness steady across the stereo field by
+// tracing a quarter circle: at center both channels sit at ~0.707 (-3 dB)
+// rather than 1.0, which matches how pan pots behave in most DAWs.
+type ConstantPowerPan struct{}
+
+func (ConstantPowerPan) Name() string { return "Constant-power" }
+
+func (ConstantPowerPan) Gains(panning float64) (left, right float64) {
+	theta := (panning + 1) * math.Pi / 4
+	return math.Cos(theta), math.Sin(theta)
+}
+
+// CompromisePan is the geometric mean of LinearPan and ConstantPowerPan, the
+// shape of the curve commonly called the -4.5dB compromise law. Because
+// LinearPan here keeps both channels at 1.0 at center, this curve's center
+// gain is about 0.84 (-1.5 dB) per channel, halfway in dB between LinearPan
+// (0 dB) and ConstantPowerPan (-3 dB). It is a common middle ground when neither a full +6 dB center boost nor a full -3 dB
+// center dip sounds right for the material.
+type CompromisePan struct{}
+
+func (CompromisePan) Name() string { return "-4.5dB compromise" }
+
+func (CompromisePan) Gains(panning float64) (left, right float64) {
+	linearLeft, linearRight := LinearPan{}.Gains(panning)
+	powerLeft, powerRight := ConstantPowerPan{}.Gains(panning)
+	return math.Sqrt(linearLeft * powerLeft), math.Sqrt(linearRight * powerRight)
+}
+
+// gainToDB converts a linear gain to decibels. A gain of 0 is reported as
+// -infinity dB.
+func gainToDB(gain float64) float64 {
+	return 20 * math.Log10(gain)
+}
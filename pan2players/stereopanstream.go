@@ -0,0 +1,179 @@
+// Copyright 2020 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"sync/atomic"
+)
+
+// rampFrames is the number of stereo frames over which gain changes are
+// ramped to avoid zipper noise, roughly 5 ms at the demo's sample rate.
+const rampFrames = sampleRate * 5 / 1000
+
+// maxITDSamples bounds the inter-aural delay line. It comfortably covers
+// spatial.MaxITD (~0.65 ms) at the demo's sample rate.
+const maxITDSamples = 64
+
+// StereoPanStream wraps a single io.ReadSeeker of interleaved stereo F32
+// frames and applies a pannable left/right gain to each frame as it is read,
+// plus an optional overall gain and per-channel inter-aural time difference
+// (ITD) delay for 3D positional audio. This replaces decoding the source
+// twice and silencing one channel per player: one decoder, one player, one
+// source of truth for playback position.
+//
+// Setters may be called concurrently with Read from another goroutine
+// (Ebitengine pulls audio on its own goroutine), so pan, pan law, gain, and
+// ITD are all stored atomically. Pan/gain are ramped linearly toward their
+// target across rampFrames on every Read call, rather than jumping
+// instantly, so changing them mid-playback doesn't click.
+type StereoPanStream struct {
+	src io.ReadSeeker
+
+	panBits  atomic.Uint64
+	panLaw   atomic.Pointer[PanLaw]
+	gainBits atomic.Uint64
+
+	leftITD, rightITD atomic.Int32
+
+	// The remaining fields are only touched from Read, which Ebitengine
+	// guarantees is never called concurrently with itself.
+	curLeft, curRight float64
+
+	leftRing, rightRing [maxITDSamples]float32
+	ringPos             int
+}
+
+// NewStereoPanStream creates a StereoPanStream reading frames from src,
+// initially centered (pan 0), at unity gain, under law.
+func NewStereoPanStream(src io.ReadSeeker, law PanLaw) *StereoPanStream {
+	s := &StereoPanStream{src: src}
+	s.panLaw.Store(&law)
+	s.gainBits.Store(math.Float64bits(1))
+	s.curLeft, s.curRight = law.Gains(0)
+	return s
+}
+
+// SetPan sets the target panning, in the range [-1, 1]. It is safe to call
+// from any goroutine.
+func (s *StereoPanStream) SetPan(pan float64) {
+	s.panBits.Store(math.Float64bits(pan))
+}
+
+// SetPanLaw sets the target pan law. It is safe to call from any goroutine.
+func (s *StereoPanStream) SetPanLaw(law PanLaw) {
+	s.panLaw.Store(&law)
+}
+
+// SetGain sets an overall linear gain applied on top of the pan law's
+// per-channel gains, e.g. for distance attenuation. It is safe to call from
+// any goroutine.
+func (s *StereoPanStream) SetGain(gain float64) {
+	s.gainBits.Store(math.Float64bits(gain))
+}
+
+// SetITD sets how many samples to delay the left and right channels by, to
+// simulate inter-aural time difference. Exactly one side is normally
+// nonzero. Values are clamped to the stream's internal delay line length. It
+// is safe to call from any goroutine.
+func (s *StereoPanStream) SetITD(leftSamples, rightSamples int) {
+	s.leftITD.Store(int32(clampITD(leftSamples)))
+	s.rightITD.Store(int32(clampITD(rightSamples)))
+}
+
+func clampITD(samples int) int {
+	if samples < 0 {
+		return 0
+	}
+	if samples > maxITDSamples-1 {
+		return maxITDSamples - 1
+	}
+	return samples
+}
+
+func (s *StereoPanStream) Read(p []byte) (int, error) {
+	n, err := s.src.Read(p)
+
+	law := *s.panLaw.Load()
+	pan := math.Float64frombits(s.panBits.Load())
+	gain := math.Float64frombits(s.gainBits.Load())
+	targetLeft, targetRight := law.Gains(pan)
+	targetLeft *= gain
+	targetRight *= gain
+	leftStep := (targetLeft - s.curLeft) / rampFrames
+	rightStep := (targetRight - s.curRight) / rampFrames
+
+	leftITD := int(s.leftITD.Load())
+	rightITD := int(s.rightITD.Load())
+
+	// Each stereo float32 frame is 8 bytes: 4 bytes left + 4 bytes right.
+	for i := 0; i+8 <= n; i += 8 {
+		s.curLeft = step(s.curLeft, targetLeft, leftStep)
+		s.curRight = step(s.curRight, targetRight, rightStep)
+		applyGain(p[i:i+4], s.curLeft)
+		applyGain(p[i+4:i+8], s.curRight)
+
+		s.leftRing[s.ringPos%maxITDSamples] = readFloat32(p[i : i+4])
+		s.rightRing[s.ringPos%maxITDSamples] = readFloat32(p[i+4 : i+8])
+		s.ringPos++
+
+		writeFloat32(p[i:i+4], s.leftRing[ringIndex(s.ringPos, leftITD, maxITDSamples)])
+		writeFloat32(p[i+4:i+8], s.rightRing[ringIndex(s.ringPos, rightITD, maxITDSamples)])
+	}
+
+	return n, err
+}
+
+// ringIndex returns the index into a ring buffer of length size holding the
+// sample written delaySamples ago, relative to a ring buffer that has just
+// advanced to ringPos.
+func ringIndex(ringPos, delaySamples, size int) int {
+	idx := (ringPos - 1 - delaySamples) % size
+	if idx < 0 {
+		idx += size
+	}
+	return idx
+}
+
+func (s *StereoPanStream) Seek(offset int64, whence int) (int64, error) {
+	return s.src.Seek(offset, whence)
+}
+
+// step advances cur toward target by delta, without overshooting it.
+func step(cur, target, delta float64) float64 {
+	cur += delta
+	if (delta >= 0 && cur > target) || (delta < 0 && cur < target) {
+		return target
+	}
+	return cur
+}
+
+// applyGain multiplies the little-endian float32 sample in b by gain,
+// in place.
+func applyGain(b []byte, gain float64) {
+	writeFloat32(b, readFloat32(b)*float32(gain))
+}
+
+// readFloat32 decodes a little-endian float32 sample from b.
+func readFloat32(b []byte) float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(b))
+}
+
+// writeFloat32 encodes a little-endian float32 sample into b.
+func writeFloat32(b []byte, sample float32) {
+	binary.LittleEndian.PutUint32(b, math.Float32bits(sample))
+}
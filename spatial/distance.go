@@ -0,0 +1,79 @@
+// Copyright 2020 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spatial
+
+// DistanceModel selects how a source's gain falls off with distance from the
+// listener. The three models mirror OpenAL's AL_INVERSE_DISTANCE[_CLAMPED]
+// and AL_LINEAR_DISTANCE.
+type DistanceModel int
+
+const (
+	// Inverse attenuates as RefDistance / (RefDistance + RolloffFactor *
+	// (distance - RefDistance)), with no clamping, so gain keeps falling off
+	// past MaxDistance.
+	Inverse DistanceModel = iota
+
+	// InverseClamped is Inverse with distance clamped to [RefDistance,
+	// MaxDistance] before attenuating, so gain never falls below the value
+	// at MaxDistance.
+	InverseClamped
+
+	// Linear attenuates linearly from 1 at RefDistance to 0 at MaxDistance,
+	// scaled by RolloffFactor. Distance is clamped to [RefDistance,
+	// MaxDistance].
+	Linear
+)
+
+// AttenuationParams configures distance attenuation for a sound source.
+type AttenuationParams struct {
+	Model DistanceModel
+
+	// RefDistance is the distance at which gain is 1 (no attenuation).
+	RefDistance float64
+
+	// MaxDistance is the distance beyond which InverseClamped and Linear
+	// stop attenuating further.
+	MaxDistance float64
+
+	// RolloffFactor scales how quickly gain falls off with distance.
+	RolloffFactor float64
+}
+
+// Gain returns the linear gain for a source at the given distance from the
+// listener.
+func (p AttenuationParams) Gain(distance float64) float64 {
+	switch p.Model {
+	case InverseClamped:
+		distance = clamp(distance, p.RefDistance, p.MaxDistance)
+		fallthrough
+	case Inverse:
+		return p.RefDistance / (p.RefDistance + p.RolloffFactor*(distance-p.RefDistance))
+	case Linear:
+		d := clamp(distance, p.RefDistance, p.MaxDistance)
+		return 1 - p.RolloffFactor*(d-p.RefDistance)/(p.MaxDistance-p.RefDistance)
+	default:
+		return 1
+	}
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
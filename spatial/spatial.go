@@ -0,0 +1,102 @@
+// Copyright 2020 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spatial
+
+import "time"
+
+// Listener is a point in world space with an orientation, against which a
+// Source's azimuth and distance are measured.
+type Listener struct {
+	Position Vec2
+
+	// Forward is the unit vector the listener faces. The zero value is
+	// treated as {0, -1} (screen "up").
+	Forward Vec2
+}
+
+func (l Listener) forward() Vec2 {
+	if l.Forward == (Vec2{}) {
+		return Vec2{0, -1}
+	}
+	return l.Forward
+}
+
+// Right returns the listener's right-facing axis, perpendicular to Forward.
+func (l Listener) Right() Vec2 {
+	f := l.forward()
+	return Vec2{-f.Y, f.X}
+}
+
+// Placement is the result of evaluating a source against a listener: enough
+// to drive a StereoPanStream's pan, gain, and ITD for one audio buffer.
+type Placement struct {
+	// Distance is the distance from the listener to the source.
+	Distance float64
+
+	// Azimuth is the source's position projected onto the listener's right
+	// axis, in the same [-1, 1] range as StereoPanStream's pan (-1 fully
+	// left, 1 fully right). Feed this into a PanLaw for ILD.
+	Azimuth float64
+
+	// Gain is the distance-attenuation gain, independent of panning.
+	Gain float64
+
+	// ITDLeftSamples and ITDRightSamples are how many samples to delay the
+	// left and right channels respectively to simulate inter-aural time
+	// difference; at most one of the two is nonzero, and both are zero when
+	// the source is straight ahead or behind.
+	ITDLeftSamples, ITDRightSamples int
+}
+
+// MaxITD is the inter-aural time difference at a full 90-degree azimuth,
+// matching the typical human head width (~0.65ms).
+const MaxITD = 650 * time.Microsecond
+
+// Compute evaluates a source at sourcePos against listener, using atten for
+// distance attenuation and sampleRate to convert ITD into samples.
+func Compute(listener Listener, sourcePos Vec2, atten AttenuationParams, sampleRate int) Placement {
+	toSource := sourcePos.Sub(listener.Position)
+	distance := toSource.Length()
+	azimuth := clamp(toSource.Normalize().Dot(listener.Right()), -1, 1)
+
+	left, right := itdSamples(azimuth, sampleRate)
+	return Placement{
+		Distance:        distance,
+		Azimuth:         azimuth,
+		Gain:            atten.Gain(distance),
+		ITDLeftSamples:  left,
+		ITDRightSamples: right,
+	}
+}
+
+// itdSamples returns the number of samples to delay the far ear by, given an
+// azimuth in [-1, 1]. The near ear (the one the source is closer to) gets no
+// delay; the far ear is delayed by up to MaxITD at a full azimuth.
+func itdSamples(azimuth float64, sampleRate int) (left, right int) {
+	maxSamples := int(MaxITD.Seconds() * float64(sampleRate))
+	delay := int(float64(maxSamples)*azimuthMagnitude(azimuth) + 0.5)
+	if azimuth > 0 {
+		// Source is to the right: the left ear is farther away.
+		return delay, 0
+	}
+	return 0, delay
+}
+
+func azimuthMagnitude(azimuth float64) float64 {
+	if azimuth < 0 {
+		return -azimuth
+	}
+	return azimuth
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spatial computes 2D positional audio parameters (distance
+// attenuation, azimuth, and inter-aural time difference) for a source and
+// listener in world space, for consumption by an audio pipeline such as
+// pan2players' StereoPanStream.
+package spatial
+
+import "math"
+
+// Vec2 is a 2D world-space vector.
+type Vec2 struct {
+	X, Y float64
+}
+
+// Sub returns v - o.
+func (v Vec2) Sub(o Vec2) Vec2 {
+	return Vec2{v.X - o.X, v.Y - o.Y}
+}
+
+// Length returns the Euclidean length of v.
+func (v Vec2) Length() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+// Normalize returns v scaled to unit length, or the zero vector if v is zero.
+func (v Vec2) Normalize() Vec2 {
+	l := v.Length()
+	if l == 0 {
+		return Vec2{}
+	}
+	return Vec2{v.X / l, v.Y / l}
+}
+
+// Dot returns the dot product of v and o.
+func (v Vec2) Dot(o Vec2) float64 {
+	return v.X*o.X + v.Y*o.Y
+}